@@ -0,0 +1,105 @@
+package prom
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// exemplarMaxRunes is the maximum number of UTF-8 runes allowed across an
+// exemplar's label names and values combined, per the OpenMetrics exemplar
+// spec.
+const exemplarMaxRunes = 128
+
+// exemplarState is the snapshot recorded by AddWithExemplar/ObserveWithExemplar.
+// It is swapped in wholesale via atomic.StorePointer/LoadPointer so recording
+// an exemplar never takes a lock on the metric write path.
+type exemplarState struct {
+	value  float64
+	labels prometheus.Labels
+	ts     time.Time
+
+	// bucketIdx is the classic histogram bucket this exemplar belongs to;
+	// unused (and left at -1) for counters, which have no buckets.
+	bucketIdx int
+}
+
+// AddWithExemplar adds v to the counter, same as Add, and atomically attaches
+// labels as an exemplar for the resulting observation. It panics if labels
+// don't satisfy the OpenMetrics exemplar constraints, matching how
+// client_golang's counters treat an invalid exemplar.
+//
+// This makes FixedPrecisionCounter implement prometheus.ExemplarAdder.
+func (fpc *FixedPrecisionCounter) AddWithExemplar(v float64, labels prometheus.Labels) {
+	if err := validateExemplarLabels(labels); err != nil {
+		panic(err)
+	}
+	fpc.Add(v)
+	atomic.StorePointer(&fpc.exemplar, unsafe.Pointer(&exemplarState{
+		value:     v,
+		labels:    labels,
+		ts:        time.Now(),
+		bucketIdx: -1,
+	}))
+}
+
+// newExemplarProto builds the dto.Exemplar wire representation of state.
+func newExemplarProto(state *exemplarState) *dto.Exemplar {
+	names := make([]string, 0, len(state.labels))
+	for name := range state.labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]*dto.LabelPair, 0, len(names))
+	for _, name := range names {
+		name, value := name, state.labels[name]
+		pairs = append(pairs, &dto.LabelPair{Name: &name, Value: &value})
+	}
+
+	value := state.value
+	return &dto.Exemplar{
+		Label:     pairs,
+		Value:     &value,
+		Timestamp: timestamppb.New(state.ts),
+	}
+}
+
+// validateExemplarLabels reports an error if labels has an invalid label name
+// or exceeds exemplarMaxRunes runes across all names and values.
+func validateExemplarLabels(labels prometheus.Labels) error {
+	runes := 0
+	for name, value := range labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("prom: exemplar label name %q is invalid", name)
+		}
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	if runes > exemplarMaxRunes {
+		return fmt.Errorf("prom: exemplar labels have %d runes, exceeding the limit of %d", runes, exemplarMaxRunes)
+	}
+	return nil
+}
+
+// Write is implemented to be useful as a prometheus counter; it extends
+// FixedPrecisionGauge.Write by attaching the most recently recorded exemplar,
+// if any.
+func (fpc *FixedPrecisionCounter) Write(out *dto.Metric) error {
+	if err := fpc.FixedPrecisionGauge.Write(out); err != nil {
+		return err
+	}
+	state := (*exemplarState)(atomic.LoadPointer(&fpc.exemplar))
+	if state == nil {
+		return nil
+	}
+	out.Counter.Exemplar = newExemplarProto(state)
+	return nil
+}
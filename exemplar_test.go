@@ -0,0 +1,79 @@
+package prom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestExemplarAdderInterface(t *testing.T) {
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, 3)
+
+	switch interface{}(c).(type) {
+	case prometheus.ExemplarAdder:
+	default:
+		t.Fatalf("FixedPrecisionCounter is not a prometheus.ExemplarAdder")
+	}
+}
+
+func TestAddWithExemplar(t *testing.T) {
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, 3)
+
+	c.AddWithExemplar(1, prometheus.Labels{"trace_id": "abc123"})
+
+	if want, got := 1.0, c.Value(); want != got {
+		t.Errorf("Expected %v, got %v.", want, got)
+	}
+
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	ex := m.GetCounter().GetExemplar()
+	if ex == nil {
+		t.Fatalf("expected an exemplar to be attached")
+	}
+	if want, got := 1.0, ex.GetValue(); want != got {
+		t.Errorf("Expected exemplar value %v, got %v.", want, got)
+	}
+	if len(ex.GetLabel()) != 1 || ex.GetLabel()[0].GetName() != "trace_id" || ex.GetLabel()[0].GetValue() != "abc123" {
+		t.Errorf("unexpected exemplar labels: %v", ex.GetLabel())
+	}
+}
+
+func TestAddWithExemplarInvalidLabelName(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Fatalf("did not panic and should have")
+		}
+	}()
+
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, 3)
+	c.AddWithExemplar(1, prometheus.Labels{"not a valid name": "x"})
+}
+
+func TestAddWithExemplarTooManyRunes(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Fatalf("did not panic and should have")
+		}
+	}()
+
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, 3)
+	c.AddWithExemplar(1, prometheus.Labels{"trace_id": strings.Repeat("a", exemplarMaxRunes+1)})
+}
@@ -7,8 +7,10 @@ package prom
 
 import (
 	"math"
+	"sync"
 	"sync/atomic"
 	"time"
+	"unsafe"
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
@@ -26,13 +28,39 @@ func NewGauge(opts prometheus.GaugeOpts, prec uint) prometheus.Gauge {
 	return NewFixedPrecisionGauge(opts, prec)
 }
 
+// maxFastPathScaled bounds the magnitude of a single scaled fast-path add to
+// half of int64's range, so no one add can overflow val on its own. The
+// accumulated val is not checked: repeated large fast-path adds (or Inc/Dec)
+// can still overflow it.
+const maxFastPathScaled = math.MaxInt64 / 2
+
 // FixedPrecisionGauge implements a prometheus Gauge/Counter metric that uses atomic
 // adds and stores for speed.
+//
+// Values are tracked in two atomic fields: val holds the common-case integral
+// value, scaled by prec, and updated with a single atomic.AddInt64; floatBits
+// holds the math.Float64bits of an unscaled float64 remainder for adds that
+// aren't exact integers once scaled, or that don't fit safely in int64. Value
+// and Write sum the two to produce the final result, so the fast path stays
+// uncontended while overflow and fractional loss are handled correctly.
+//
+// Set replaces both fields at once, so it and Value/Write take setMu to
+// make that replacement atomic as a unit: without it, a reader between Set's
+// two stores could sum a new val against a stale floatBits (or vice versa)
+// and see a value that never actually existed. Add/Inc/Dec never touch both
+// fields in the same call, so they stay lock-free.
 type FixedPrecisionGauge struct {
-	val  int64
-	prec uint
+	val       int64
+	floatBits uint64
+	prec      uint
+
+	setMu sync.Mutex
 
 	desc *prometheus.Desc
+
+	// labels holds this metric's label pairs when it's a child of a
+	// FixedPrecisionCounterVec/FixedPrecisionGaugeVec; nil otherwise.
+	labels []*dto.LabelPair
 }
 
 // NewFixedPrecisionGauge returns a populated fixed-precision counter.
@@ -49,9 +77,45 @@ func NewFixedPrecisionGauge(opts prometheus.GaugeOpts, prec uint) *FixedPrecisio
 	}
 }
 
-// Set stores the value in the counter.
+// scaledFastPath reports whether val, once scaled by prec, can be added via
+// the int64 fast path: it must be an exact integer and small enough, once
+// scaled, to leave headroom in int64.
+func (fpg *FixedPrecisionGauge) scaledFastPath(val float64) (int64, bool) {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return 0, false
+	}
+	scaled := val * float64(fpg.prec)
+	if scaled != math.Trunc(scaled) || math.Abs(scaled) > maxFastPathScaled {
+		return 0, false
+	}
+	return int64(scaled), true
+}
+
+// addFloat adds delta to the float64 remainder via a CAS loop, keeping the
+// slow path lock-free.
+func (fpg *FixedPrecisionGauge) addFloat(delta float64) {
+	for {
+		oldBits := atomic.LoadUint64(&fpg.floatBits)
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + delta)
+		if atomic.CompareAndSwapUint64(&fpg.floatBits, oldBits, newBits) {
+			return
+		}
+	}
+}
+
+// Set stores the value in the counter. It holds setMu across both field
+// stores so a concurrent Value/Write (or another concurrent Set) can never
+// observe a torn combination of val and floatBits.
 func (fpg *FixedPrecisionGauge) Set(val float64) {
-	atomic.StoreInt64(&fpg.val, int64(val)*int64(fpg.prec))
+	fpg.setMu.Lock()
+	defer fpg.setMu.Unlock()
+	if scaled, ok := fpg.scaledFastPath(val); ok {
+		atomic.StoreInt64(&fpg.val, scaled)
+		atomic.StoreUint64(&fpg.floatBits, 0)
+		return
+	}
+	atomic.StoreInt64(&fpg.val, 0)
+	atomic.StoreUint64(&fpg.floatBits, math.Float64bits(val))
 }
 
 // add maps delta into the appropriate precision and adds it to val.
@@ -69,9 +133,17 @@ func (fpg *FixedPrecisionGauge) Dec() {
 	fpg.add(-1)
 }
 
-// Add generically adds delta to the value stored by counter.
+// Add generically adds delta to the value stored by counter. When delta,
+// once scaled by prec, is an exact integer that fits safely in int64, it
+// takes a single atomic.AddInt64; otherwise it falls back to a CAS loop on
+// the float64 remainder so large or fractional adds are never silently
+// wrapped.
 func (fpg *FixedPrecisionGauge) Add(delta float64) {
-	atomic.AddInt64(&fpg.val, int64(delta*float64(fpg.prec)))
+	if scaled, ok := fpg.scaledFastPath(delta); ok {
+		atomic.AddInt64(&fpg.val, scaled)
+		return
+	}
+	fpg.addFloat(delta)
 }
 
 // Sub is the inverse of Add.
@@ -81,14 +153,29 @@ func (fpg *FixedPrecisionGauge) Sub(val float64) {
 
 // Write is implemented to be useful as a prometheus counter.
 func (fpg *FixedPrecisionGauge) Write(out *dto.Metric) error {
-	f := float64(atomic.LoadInt64(&fpg.val)) / float64(fpg.prec)
+	f := fpg.Value()
 	out.Counter = &dto.Counter{Value: &f}
+	out.Label = fpg.labels
 	return nil
 }
 
-// Value returns a float64 representation of the current value stored.
+// setVecLabels turns a standalone gauge/counter into a child of a metric
+// vector by pointing it at the vector's shared Desc and stamping its label
+// pairs, so Collect can emit it without an extra wrapper type.
+func (fpg *FixedPrecisionGauge) setVecLabels(desc *prometheus.Desc, labels []*dto.LabelPair) {
+	fpg.desc = desc
+	fpg.labels = labels
+}
+
+// Value returns a float64 representation of the current value stored. It
+// holds setMu across both field loads so a concurrent Set can never leave it
+// reading a torn combination of val and floatBits.
 func (fpg *FixedPrecisionGauge) Value() float64 {
-	return float64(atomic.LoadInt64(&fpg.val)) / float64(fpg.prec)
+	fpg.setMu.Lock()
+	defer fpg.setMu.Unlock()
+	intPart := float64(atomic.LoadInt64(&fpg.val)) / float64(fpg.prec)
+	floatPart := math.Float64frombits(atomic.LoadUint64(&fpg.floatBits))
+	return intPart + floatPart
 }
 
 // The following three methods exist to make this behave with Prometheus
@@ -109,9 +196,6 @@ func (fpg *FixedPrecisionGauge) Collect(mc chan<- prometheus.Metric) {
 }
 
 // SetToCurrentTime sets the Gauge to the current Unix time in seconds.
-//
-// Beware that if precision is set too high (greater than 9) it can overflow
-// the underlying int64.
 func (fpg *FixedPrecisionGauge) SetToCurrentTime() {
 	fpg.Set(float64(time.Now().Unix()))
 }
@@ -120,6 +204,10 @@ func (fpg *FixedPrecisionGauge) SetToCurrentTime() {
 // guarantees as a prometheus.Counter where negative adds panic.
 type FixedPrecisionCounter struct {
 	FixedPrecisionGauge
+
+	// exemplar points at an *exemplarState and is swapped atomically by
+	// AddWithExemplar so recording one never blocks a concurrent Write.
+	exemplar unsafe.Pointer
 }
 
 // NewFixedPrecisionCounter creates a FixedPrecisionCounter based on the
@@ -113,6 +113,54 @@ func TestSetToCurrentTime(t *testing.T) {
 	}
 }
 
+func TestFixedPrecisionOverflowFallback(t *testing.T) {
+	c := NewFixedPrecisionGauge(prometheus.GaugeOpts{
+		Name: "test",
+		Help: "test help",
+	}, 3)
+
+	// A value whose scaled representation no longer fits safely in int64
+	// must fall back to the float path instead of silently wrapping.
+	c.Add(1e18)
+	want := 1e18
+	if expected, got := want, c.Value(); expected != got {
+		t.Errorf("Expected %v, got %v.", expected, got)
+	}
+
+	c.Add(1)
+	want = 1e18 + 1
+	if expected, got := want, c.Value(); expected != got {
+		t.Errorf("Expected %v, got %v.", expected, got)
+	}
+}
+
+func TestFixedPrecisionAddInf(t *testing.T) {
+	c := NewFixedPrecisionGauge(prometheus.GaugeOpts{
+		Name: "test",
+		Help: "test help",
+	}, 3)
+
+	c.Add(math.Inf(1))
+	if got := c.Value(); !math.IsInf(got, 1) {
+		t.Errorf("Expected +Inf, got %v.", got)
+	}
+}
+
+func TestSetToCurrentTimeHighPrecision(t *testing.T) {
+	c := NewFixedPrecisionGauge(prometheus.GaugeOpts{
+		Name: "test",
+		Help: "test help",
+	}, 12)
+
+	c.SetToCurrentTime()
+	n := time.Now()
+
+	delta := math.Abs(c.Value() - float64(n.Unix()))
+	if !(delta <= 1) {
+		t.Fatalf("SetToCurrentTime at high precision was off from time.Now(): got: %v, want: <= 1", delta)
+	}
+}
+
 func TestCounterDirection(t *testing.T) {
 	defer func() {
 		if e := recover(); e == nil {
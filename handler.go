@@ -0,0 +1,61 @@
+package prom
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// bufPool reuses the buffers Handler and Pusher encode metrics into, instead
+// of allocating one per request/push the way promhttp does.
+var bufPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// Handler returns an http.Handler that gathers reg and writes its metrics in
+// whichever of the Prometheus text, OpenMetrics text, or protobuf delimited
+// formats the request negotiates via its Accept header, matching
+// promhttp.HandlerFor's content negotiation but encoding into a pooled
+// bytes.Buffer rather than a fresh allocation per request.
+func Handler(reg *prometheus.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mfs, err := reg.Gather()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+
+		buf := bufPool.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer bufPool.Put(buf)
+
+		if err := encodeMetricFamilies(buf, mfs, format); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", string(format))
+		w.Write(buf.Bytes())
+	})
+}
+
+// encodeMetricFamilies writes mfs into buf in format, closing the encoder
+// when the format requires a trailer (OpenMetrics text ends with "# EOF\n").
+func encodeMetricFamilies(buf *bytes.Buffer, mfs []*dto.MetricFamily, format expfmt.Format) error {
+	enc := expfmt.NewEncoder(buf, format)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
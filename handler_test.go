@@ -0,0 +1,52 @@
+package prom
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHandlerServesText(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "test help",
+	}, 0)
+	c.Inc()
+	reg.MustRegister(c)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "requests_total 1") {
+		t.Errorf("expected body to contain the counter's value, got %q", body)
+	}
+}
+
+func TestHandlerServesOpenMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "test help",
+	}, 0)
+	c.Inc()
+	reg.MustRegister(c)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("expected OpenMetrics body to end with the EOF marker, got %q", body)
+	}
+}
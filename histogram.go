@@ -0,0 +1,282 @@
+package prom
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// HistogramOpts configures a FixedPrecisionHistogram. It mirrors
+// prometheus.HistogramOpts, adding NativeHistogramSchema to opt into a
+// sparse, exponentially-bucketed native histogram alongside the classic one.
+type HistogramOpts struct {
+	Namespace   string
+	Subsystem   string
+	Name        string
+	Help        string
+	ConstLabels prometheus.Labels
+
+	// Buckets are the classic upper bounds; each observation increments the
+	// first bucket whose bound is >= the observed value. A +Inf bucket is
+	// implicit, matching prometheus.Histogram.
+	Buckets []float64
+
+	// NativeHistogramSchema selects the sparse exponential bucketing scheme
+	// (base 2^(2^-schema)) to track in addition to the classic buckets.
+	// Valid schemas are 0 through 8; leave nil to disable native histogram
+	// tracking.
+	NativeHistogramSchema *int32
+}
+
+// FixedPrecisionHistogram implements a prometheus Histogram/Observer that
+// uses atomic per-bucket int64 counters and a FixedPrecisionGauge for the
+// sum, mirroring the fast path FixedPrecisionCounter/FixedPrecisionGauge use
+// for Add/Inc.
+type FixedPrecisionHistogram struct {
+	desc *prometheus.Desc
+
+	upperBounds  []float64 // ascending, excludes the implicit +Inf bound
+	bucketCounts []int64   // atomic; len(upperBounds)+1, last slot is the +Inf overflow
+	count        int64     // atomic total observation count
+	sum          FixedPrecisionGauge
+
+	schemaEnabled  bool
+	schema         int32
+	nativeBounds   []float64 // precomputed exponential bounds table for schema > 0
+	zeroCount      int64     // atomic
+	positiveCounts sync.Map  // int32 bucket index -> *int64
+	negativeCounts sync.Map  // int32 bucket index -> *int64
+
+	exemplar unsafe.Pointer // *exemplarState, swapped atomically
+}
+
+// NewFixedPrecisionHistogram returns a populated fixed-precision histogram.
+func NewFixedPrecisionHistogram(opts HistogramOpts, prec uint) *FixedPrecisionHistogram {
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		nil,
+		opts.ConstLabels,
+	)
+
+	bounds := append([]float64(nil), opts.Buckets...)
+	sort.Float64s(bounds)
+
+	h := &FixedPrecisionHistogram{
+		desc:         desc,
+		upperBounds:  bounds,
+		bucketCounts: make([]int64, len(bounds)+1),
+		sum:          FixedPrecisionGauge{prec: uint(math.Pow10(int(prec)))},
+	}
+	if opts.NativeHistogramSchema != nil {
+		h.schemaEnabled = true
+		h.schema = *opts.NativeHistogramSchema
+		if h.schema > 0 {
+			h.nativeBounds = nativeExponentialBounds(h.schema)
+		}
+	}
+	return h
+}
+
+// Observe records v in the classic buckets, the native histogram buckets (if
+// enabled), and the running sum and count.
+func (h *FixedPrecisionHistogram) Observe(v float64) {
+	idx := sort.SearchFloat64s(h.upperBounds, v)
+	atomic.AddInt64(&h.bucketCounts[idx], 1)
+	atomic.AddInt64(&h.count, 1)
+	h.sum.Add(v)
+	if h.schemaEnabled {
+		h.observeNative(v)
+	}
+}
+
+// ObserveWithExemplar observes v, same as Observe, and atomically attaches
+// labels as an exemplar on the classic bucket v landed in.
+//
+// This makes FixedPrecisionHistogram implement prometheus.ExemplarObserver.
+func (h *FixedPrecisionHistogram) ObserveWithExemplar(v float64, labels prometheus.Labels) {
+	if err := validateExemplarLabels(labels); err != nil {
+		panic(err)
+	}
+	idx := sort.SearchFloat64s(h.upperBounds, v)
+	atomic.AddInt64(&h.bucketCounts[idx], 1)
+	atomic.AddInt64(&h.count, 1)
+	h.sum.Add(v)
+	if h.schemaEnabled {
+		h.observeNative(v)
+	}
+	atomic.StorePointer(&h.exemplar, unsafe.Pointer(&exemplarState{
+		value:     v,
+		labels:    labels,
+		ts:        time.Now(),
+		bucketIdx: idx,
+	}))
+}
+
+// observeNative maps v onto the native histogram's zero, positive, or
+// negative bucket counters.
+func (h *FixedPrecisionHistogram) observeNative(v float64) {
+	switch {
+	case v == 0:
+		atomic.AddInt64(&h.zeroCount, 1)
+	case v > 0:
+		incrementNativeBucket(&h.positiveCounts, nativeBucketIndex(v, h.schema, h.nativeBounds))
+	default:
+		incrementNativeBucket(&h.negativeCounts, nativeBucketIndex(-v, h.schema, h.nativeBounds))
+	}
+}
+
+// nativeExponentialBounds returns the 2^schema fractional bucket boundaries
+// client_golang's own native histograms precompute for a positive schema:
+// bounds[i] == 0.5*factor^i where factor is 2^(2^-schema), so bounds[0] ==
+// 0.5 and bounds[len-1] approaches 1, each bound marking where v's Frexp
+// fraction (in [0.5, 1)) crosses into the next bucket within the current
+// binade.
+func nativeExponentialBounds(schema int32) []float64 {
+	bounds := make([]float64, 1<<uint(schema))
+	factor := math.Exp2(math.Exp2(-float64(schema)))
+	bound := 0.5
+	for i := range bounds {
+		bounds[i] = bound
+		bound *= factor
+	}
+	return bounds
+}
+
+// nativeBucketIndex maps a positive observation to its native-histogram
+// bucket index for the given schema, the same way client_golang's native
+// histograms do. v == frac * 2^exp with frac in [0.5, 1) (math.Frexp). For
+// schema > 0, bounds (from nativeExponentialBounds) already covers [0.5, 1)
+// and locates frac directly; (exp-1)*len(bounds) then picks the binade. For
+// schema <= 0, each bucket folds 2^-schema consecutive binades together,
+// with the frac == 0.5 case adjusted down a binade since Frexp treats powers
+// of two as starting their higher binade.
+func nativeBucketIndex(v float64, schema int32, bounds []float64) int32 {
+	frac, exp := math.Frexp(v)
+	if schema > 0 {
+		i := sort.SearchFloat64s(bounds, frac)
+		return int32((exp-1)*len(bounds) + i)
+	}
+	if frac == 0.5 {
+		exp--
+	}
+	offset := (1 << uint(-schema)) - 1
+	return int32((exp + offset) >> uint(-schema))
+}
+
+// incrementNativeBucket atomically increments the counter for idx in m,
+// creating it if this is the first observation in that bucket.
+func incrementNativeBucket(m *sync.Map, idx int32) {
+	if existing, ok := m.Load(idx); ok {
+		atomic.AddInt64(existing.(*int64), 1)
+		return
+	}
+	count := new(int64)
+	*count = 1
+	if actual, loaded := m.LoadOrStore(idx, count); loaded {
+		atomic.AddInt64(actual.(*int64), 1)
+	}
+}
+
+// nativeBucketEntry is one populated native-histogram bucket, read out of a
+// sync.Map for span/delta encoding.
+type nativeBucketEntry struct {
+	idx   int32
+	count int64
+}
+
+// nativeSpansAndDeltas reads every populated bucket out of m and encodes them
+// as OpenMetrics/Prometheus native-histogram spans and deltas: each span
+// covers a run of contiguous bucket indices, and each delta is relative to
+// the previous populated bucket's count (zero for the first).
+func nativeSpansAndDeltas(m *sync.Map) ([]*dto.BucketSpan, []int64) {
+	var entries []nativeBucketEntry
+	m.Range(func(key, value interface{}) bool {
+		if count := atomic.LoadInt64(value.(*int64)); count > 0 {
+			entries = append(entries, nativeBucketEntry{idx: key.(int32), count: count})
+		}
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].idx < entries[j].idx })
+
+	var spans []*dto.BucketSpan
+	var deltas []int64
+	var prevIdx int32
+	var prevCount int64
+	for i, e := range entries {
+		switch {
+		case i == 0:
+			offset := e.idx
+			length := uint32(1)
+			spans = append(spans, &dto.BucketSpan{Offset: &offset, Length: &length})
+			deltas = append(deltas, e.count)
+		case e.idx == prevIdx+1:
+			*spans[len(spans)-1].Length++
+			deltas = append(deltas, e.count-prevCount)
+		default:
+			offset := e.idx - prevIdx - 1
+			length := uint32(1)
+			spans = append(spans, &dto.BucketSpan{Offset: &offset, Length: &length})
+			deltas = append(deltas, e.count-prevCount)
+		}
+		prevIdx, prevCount = e.idx, e.count
+	}
+	return spans, deltas
+}
+
+// Write is implemented to be useful as a prometheus histogram.
+func (h *FixedPrecisionHistogram) Write(out *dto.Metric) error {
+	buckets := make([]*dto.Bucket, len(h.upperBounds))
+	var cumulative int64
+	for i, bound := range h.upperBounds {
+		cumulative += atomic.LoadInt64(&h.bucketCounts[i])
+		count := uint64(cumulative)
+		b := bound
+		buckets[i] = &dto.Bucket{CumulativeCount: &count, UpperBound: &b}
+	}
+
+	count := uint64(atomic.LoadInt64(&h.count))
+	sum := h.sum.Value()
+	histogram := &dto.Histogram{
+		SampleCount: &count,
+		SampleSum:   &sum,
+		Bucket:      buckets,
+	}
+
+	if h.schemaEnabled {
+		schema := h.schema
+		zeroCount := uint64(atomic.LoadInt64(&h.zeroCount))
+		histogram.Schema = &schema
+		histogram.ZeroCount = &zeroCount
+		histogram.PositiveSpan, histogram.PositiveDelta = nativeSpansAndDeltas(&h.positiveCounts)
+		histogram.NegativeSpan, histogram.NegativeDelta = nativeSpansAndDeltas(&h.negativeCounts)
+	}
+
+	if state := (*exemplarState)(atomic.LoadPointer(&h.exemplar)); state != nil && state.bucketIdx >= 0 && state.bucketIdx < len(buckets) {
+		buckets[state.bucketIdx].Exemplar = newExemplarProto(state)
+	}
+
+	out.Histogram = histogram
+	return nil
+}
+
+// Desc returns this histogram's prometheus description.
+func (h *FixedPrecisionHistogram) Desc() *prometheus.Desc {
+	return h.desc
+}
+
+// Describe sends the histogram's description to the chan.
+func (h *FixedPrecisionHistogram) Describe(dc chan<- *prometheus.Desc) {
+	dc <- h.desc
+}
+
+// Collect sends the histogram to the chan.
+func (h *FixedPrecisionHistogram) Collect(mc chan<- prometheus.Metric) {
+	mc <- h
+}
@@ -0,0 +1,169 @@
+package prom
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestFixedPrecisionHistogramClassicBuckets(t *testing.T) {
+	h := NewFixedPrecisionHistogram(HistogramOpts{
+		Name:    "test",
+		Help:    "test help",
+		Buckets: []float64{1, 5, 10},
+	}, 3)
+
+	for _, v := range []float64{0.5, 2, 2, 7, 20} {
+		h.Observe(v)
+	}
+
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	hist := m.GetHistogram()
+	if want, got := uint64(5), hist.GetSampleCount(); want != got {
+		t.Errorf("Expected sample count %d, got %d.", want, got)
+	}
+	if want, got := 0.5+2+2+7+20, hist.GetSampleSum(); want != got {
+		t.Errorf("Expected sample sum %v, got %v.", want, got)
+	}
+
+	wantCumulative := []uint64{1, 3, 4} // <=1, <=5, <=10
+	if len(hist.GetBucket()) != len(wantCumulative) {
+		t.Fatalf("Expected %d buckets, got %d.", len(wantCumulative), len(hist.GetBucket()))
+	}
+	for i, b := range hist.GetBucket() {
+		if b.GetCumulativeCount() != wantCumulative[i] {
+			t.Errorf("bucket %d: expected cumulative count %d, got %d", i, wantCumulative[i], b.GetCumulativeCount())
+		}
+	}
+}
+
+func TestFixedPrecisionHistogramNativeSchema(t *testing.T) {
+	schema := int32(2)
+	h := NewFixedPrecisionHistogram(HistogramOpts{
+		Name:                  "test",
+		Help:                  "test help",
+		NativeHistogramSchema: &schema,
+	}, 3)
+
+	h.Observe(0)
+	h.Observe(4)
+	h.Observe(-4)
+
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	hist := m.GetHistogram()
+	if want, got := schema, hist.GetSchema(); want != got {
+		t.Errorf("Expected schema %d, got %d.", want, got)
+	}
+	if want, got := uint64(1), hist.GetZeroCount(); want != got {
+		t.Errorf("Expected zero count %d, got %d.", want, got)
+	}
+	if len(hist.GetPositiveSpan()) != 1 || len(hist.GetPositiveDelta()) != 1 {
+		t.Errorf("Expected exactly one populated positive bucket, got spans=%v deltas=%v", hist.GetPositiveSpan(), hist.GetPositiveDelta())
+	}
+	if len(hist.GetNegativeSpan()) != 1 || len(hist.GetNegativeDelta()) != 1 {
+		t.Errorf("Expected exactly one populated negative bucket, got spans=%v deltas=%v", hist.GetNegativeSpan(), hist.GetNegativeDelta())
+	}
+}
+
+func TestNativeBucketIndex(t *testing.T) {
+	cases := []struct {
+		v      float64
+		schema int32
+		want   int32
+	}{
+		// schema 0: bucket i covers (2^(i-1), 2^i]; 1.5 and 1.9 both fall in
+		// (1, 2] and must land in the same bucket, not the zero bucket.
+		{v: 1.5, schema: 0, want: 1},
+		{v: 1.9, schema: 0, want: 1},
+		{v: 2.0, schema: 0, want: 1},
+		{v: 2.1, schema: 0, want: 2},
+		// schema 2: exact powers of two from client_golang's own mapping.
+		{v: 1.0, schema: 2, want: 0},
+		{v: 2.0, schema: 2, want: 4},
+		// schema 2, non-power-of-two: bounds are
+		// [0.5, 0.5946..., 0.7071..., 0.8409...]. Frexp(1.5) = (0.75, 1), and
+		// 0.75 sorts to index 3 (the first bound >= 0.75 is 0.8409...), so
+		// bucket = (1-1)*4 + 3 = 3.
+		{v: 1.5, schema: 2, want: 3},
+		// schema 1, non-power-of-two: bounds are [0.5, 0.7071...]. Frexp(1.3)
+		// = (0.65, 1), and 0.65 sorts to index 1 (0.7071... is the first
+		// bound >= 0.65), so bucket = (1-1)*2 + 1 = 1.
+		{v: 1.3, schema: 1, want: 1},
+		// schema 1, non-power-of-two: Frexp(3.0) = (0.75, 2), and 0.75 is
+		// past every bound in [0.5, 0.7071...], so it sorts to index 2,
+		// giving bucket = (2-1)*2 + 2 = 4.
+		{v: 3.0, schema: 1, want: 4},
+	}
+
+	for _, c := range cases {
+		var bounds []float64
+		if c.schema > 0 {
+			bounds = nativeExponentialBounds(c.schema)
+		}
+		if got := nativeBucketIndex(c.v, c.schema, bounds); got != c.want {
+			t.Errorf("nativeBucketIndex(%v, %d): expected %d, got %d", c.v, c.schema, c.want, got)
+		}
+	}
+}
+
+func TestFixedPrecisionHistogramNativeSchemaNonPowerOfTwo(t *testing.T) {
+	schema := int32(0)
+	h := NewFixedPrecisionHistogram(HistogramOpts{
+		Name:                  "test",
+		Help:                  "test help",
+		NativeHistogramSchema: &schema,
+	}, 3)
+
+	// 1.5 and 1.9 both belong in bucket 1; they must collapse into a single
+	// populated bucket with a combined count, not two buckets or the zero
+	// bucket.
+	h.Observe(1.5)
+	h.Observe(1.9)
+
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	hist := m.GetHistogram()
+	if want, got := uint64(0), hist.GetZeroCount(); want != got {
+		t.Errorf("Expected zero count %d, got %d.", want, got)
+	}
+	if len(hist.GetPositiveDelta()) != 1 {
+		t.Fatalf("Expected exactly one populated positive bucket, got deltas=%v", hist.GetPositiveDelta())
+	}
+	if want, got := int64(2), hist.GetPositiveDelta()[0]; want != got {
+		t.Errorf("Expected bucket count %d, got %d.", want, got)
+	}
+}
+
+func TestFixedPrecisionHistogramObserveWithExemplar(t *testing.T) {
+	h := NewFixedPrecisionHistogram(HistogramOpts{
+		Name:    "test",
+		Help:    "test help",
+		Buckets: []float64{1, 5, 10},
+	}, 3)
+
+	h.ObserveWithExemplar(2, map[string]string{"trace_id": "abc123"})
+
+	m := &dto.Metric{}
+	if err := h.Write(m); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	buckets := m.GetHistogram().GetBucket()
+	if len(buckets) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d.", len(buckets))
+	}
+	if ex := buckets[1].GetExemplar(); ex == nil || ex.GetValue() != 2 {
+		t.Errorf("expected exemplar with value 2 on the <=5 bucket, got %v", buckets[1].GetExemplar())
+	}
+}
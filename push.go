@@ -0,0 +1,126 @@
+package prom
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Pusher pushes the current value of its registered collectors to a
+// Prometheus Pushgateway, for batch jobs that don't live long enough to be
+// scraped. It encodes directly via expfmt instead of going through
+// github.com/prometheus/client_golang/prometheus/push.
+type Pusher struct {
+	base        string
+	job         string
+	groupLabels prometheus.Labels
+
+	client *http.Client
+	reg    *prometheus.Registry
+}
+
+// NewPusher returns a Pusher that pushes to the Pushgateway at addr under job.
+func NewPusher(addr, job string) *Pusher {
+	return &Pusher{
+		base:        strings.TrimRight(addr, "/"),
+		job:         job,
+		groupLabels: prometheus.Labels{},
+		client:      http.DefaultClient,
+		reg:         prometheus.NewRegistry(),
+	}
+}
+
+// Grouping adds a grouping label identifying this push's instance within job,
+// e.g. Grouping("instance", "host1:1234"). It returns p for chaining.
+func (p *Pusher) Grouping(name, value string) *Pusher {
+	p.groupLabels[name] = value
+	return p
+}
+
+// Collector registers c to be gathered and pushed by Add/Push. It returns p
+// for chaining.
+func (p *Pusher) Collector(c prometheus.Collector) *Pusher {
+	p.reg.MustRegister(c)
+	return p
+}
+
+// Client overrides the http.Client used to reach the Pushgateway. It returns
+// p for chaining.
+func (p *Pusher) Client(client *http.Client) *Pusher {
+	p.client = client
+	return p
+}
+
+// Push pushes the registered collectors' current metrics to the Pushgateway,
+// replacing any group previously pushed under the same job and grouping
+// labels.
+func (p *Pusher) Push(ctx context.Context) error {
+	return p.push(ctx, http.MethodPut)
+}
+
+// Add pushes the registered collectors' current metrics to the Pushgateway,
+// merging them into any group previously pushed under the same job and
+// grouping labels instead of replacing it.
+func (p *Pusher) Add(ctx context.Context) error {
+	return p.push(ctx, http.MethodPost)
+}
+
+func (p *Pusher) push(ctx context.Context, method string) error {
+	mfs, err := p.reg.Gather()
+	if err != nil {
+		return fmt.Errorf("prom: gathering metrics to push: %w", err)
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := encodeMetricFamilies(buf, mfs, expfmt.NewFormat(expfmt.TypeProtoDelim)); err != nil {
+		return fmt.Errorf("prom: encoding metrics to push: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.groupingURL(), bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("prom: building push request: %w", err)
+	}
+	req.Header.Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeProtoDelim)))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("prom: pushing metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("prom: unexpected status pushing metrics: %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// groupingURL builds the Pushgateway grouping-key URL:
+// <base>/metrics/job/<job>/<label>/<value>/..., with grouping labels sorted
+// by name so the URL is deterministic.
+func (p *Pusher) groupingURL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/metrics/job/%s", p.base, url.PathEscape(p.job))
+
+	names := make([]string, 0, len(p.groupLabels))
+	for name := range p.groupLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "/%s/%s", url.PathEscape(name), url.PathEscape(p.groupLabels[name]))
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package prom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPusherPush(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewFixedPrecisionCounter(prometheus.CounterOpts{
+		Name: "jobs_total",
+		Help: "test help",
+	}, 0)
+	c.Inc()
+
+	p := NewPusher(srv.URL, "cleanup").
+		Grouping("instance", "host1").
+		Collector(c)
+
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("expected PUT, got %s", gotMethod)
+	}
+	if want := "/metrics/job/cleanup/instance/host1"; gotPath != want {
+		t.Errorf("expected path %q, got %q", want, gotPath)
+	}
+}
+
+func TestPusherAddUsesPost(t *testing.T) {
+	var gotMethod string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := NewPusher(srv.URL, "cleanup")
+	if err := p.Add(context.Background()); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if gotMethod != http.MethodPost {
+		t.Errorf("expected POST, got %s", gotMethod)
+	}
+}
+
+func TestPusherErrorsOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	p := NewPusher(srv.URL, "cleanup")
+	if err := p.Push(context.Background()); err == nil {
+		t.Fatalf("expected an error for a non-2xx response")
+	}
+}
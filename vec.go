@@ -0,0 +1,294 @@
+package prom
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// labelValueSeparator is written between label values before hashing so
+// that, e.g., ("ab", "c") and ("a", "bc") never hash the same.
+const labelValueSeparator = '\xff'
+
+// vecChild is implemented by FixedPrecisionCounter and FixedPrecisionGauge so
+// a vec can turn a freshly constructed one into a labeled child, sharing the
+// vector's Desc the way prometheus.MetricVec children do.
+type vecChild interface {
+	prometheus.Metric
+	setVecLabels(desc *prometheus.Desc, labels []*dto.LabelPair)
+}
+
+// vecEntry is one child of a vec, keyed by its ordered label values.
+type vecEntry struct {
+	values []string
+	metric vecChild
+}
+
+// vec is the hash-keyed child registry shared by FixedPrecisionCounterVec and
+// FixedPrecisionGaugeVec. Describe/Collect walk children via sync.Map.Range,
+// so the read path never blocks on mu; mu only serializes the rare path of
+// creating or deleting a child.
+type vec struct {
+	desc       *prometheus.Desc
+	labelNames []string
+
+	mu       sync.Mutex
+	children sync.Map // uint64 label-value hash -> []*vecEntry
+}
+
+// hash computes a stable xxhash of an ordered set of label values. This is
+// our own hashing scheme, internally consistent within a vec's children map;
+// it is not the same hash client_golang's CounterVec/GaugeVec use internally
+// (their WithLabelValues hashes with an FNV-1a variant), and the two are not
+// interchangeable.
+func (v *vec) hash(values []string) uint64 {
+	h := xxhash.New()
+	for _, val := range values {
+		h.WriteString(val)
+		h.Write([]byte{labelValueSeparator})
+	}
+	return h.Sum64()
+}
+
+func findVecEntry(entries []*vecEntry, values []string) *vecEntry {
+	for _, e := range entries {
+		if equalStrings(e.values, values) {
+			return e
+		}
+	}
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func labelPairs(names, values []string) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, len(names))
+	for i, name := range names {
+		name, value := name, values[i]
+		pairs[i] = &dto.LabelPair{Name: &name, Value: &value}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].GetName() < pairs[j].GetName() })
+	return pairs
+}
+
+// child returns the existing child for values, creating one with newChild if
+// this is the first observation for that combination of label values. It
+// panics if values doesn't have one entry per label name, matching
+// prometheus.CounterVec/GaugeVec's WithLabelValues contract.
+func (v *vec) child(values []string, newChild func() vecChild) vecChild {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("prom: inconsistent label cardinality: expected %d label values, got %d", len(v.labelNames), len(values)))
+	}
+
+	hash := v.hash(values)
+
+	if existing, ok := v.children.Load(hash); ok {
+		if e := findVecEntry(existing.([]*vecEntry), values); e != nil {
+			return e.metric
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var entries []*vecEntry
+	if existing, ok := v.children.Load(hash); ok {
+		entries = existing.([]*vecEntry)
+		if e := findVecEntry(entries, values); e != nil {
+			return e.metric
+		}
+	}
+
+	metric := newChild()
+	metric.setVecLabels(v.desc, labelPairs(v.labelNames, values))
+	v.children.Store(hash, append(entries, &vecEntry{
+		values: append([]string(nil), values...),
+		metric: metric,
+	}))
+	return metric
+}
+
+func (v *vec) orderedValues(labels prometheus.Labels) []string {
+	values := make([]string, len(v.labelNames))
+	for i, name := range v.labelNames {
+		values[i] = labels[name]
+	}
+	return values
+}
+
+func (v *vec) deleteLabelValues(values []string) bool {
+	hash := v.hash(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	existing, ok := v.children.Load(hash)
+	if !ok {
+		return false
+	}
+	entries := existing.([]*vecEntry)
+	for i, e := range entries {
+		if !equalStrings(e.values, values) {
+			continue
+		}
+		remaining := append(entries[:i:i], entries[i+1:]...)
+		if len(remaining) == 0 {
+			v.children.Delete(hash)
+		} else {
+			v.children.Store(hash, remaining)
+		}
+		return true
+	}
+	return false
+}
+
+func (v *vec) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.children.Range(func(key, _ interface{}) bool {
+		v.children.Delete(key)
+		return true
+	})
+}
+
+// Describe sends the vector's single, shared description to the chan.
+func (v *vec) Describe(dc chan<- *prometheus.Desc) {
+	dc <- v.desc
+}
+
+// Collect sends every child's current metric to the chan.
+func (v *vec) Collect(mc chan<- prometheus.Metric) {
+	v.children.Range(func(_, value interface{}) bool {
+		for _, e := range value.([]*vecEntry) {
+			mc <- e.metric
+		}
+		return true
+	})
+}
+
+// FixedPrecisionCounterVec is a prometheus.Collector that manages a
+// FixedPrecisionCounter per distinct combination of label values, the way
+// prometheus.CounterVec does for regular counters.
+type FixedPrecisionCounterVec struct {
+	vec
+
+	opts prometheus.CounterOpts
+	prec uint
+}
+
+// NewFixedPrecisionCounterVec returns a populated fixed-precision counter
+// vector.
+func NewFixedPrecisionCounterVec(opts prometheus.CounterOpts, labelNames []string, prec uint) *FixedPrecisionCounterVec {
+	return &FixedPrecisionCounterVec{
+		vec: vec{
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+				opts.Help,
+				labelNames,
+				opts.ConstLabels,
+			),
+			labelNames: labelNames,
+		},
+		opts: opts,
+		prec: prec,
+	}
+}
+
+// WithLabelValues returns the counter for the given, ordered label values,
+// creating it if this is the first time they've been observed.
+func (v *FixedPrecisionCounterVec) WithLabelValues(lvs ...string) *FixedPrecisionCounter {
+	return v.child(lvs, func() vecChild {
+		return NewFixedPrecisionCounter(v.opts, v.prec)
+	}).(*FixedPrecisionCounter)
+}
+
+// With is the prometheus.Labels equivalent of WithLabelValues.
+func (v *FixedPrecisionCounterVec) With(labels prometheus.Labels) *FixedPrecisionCounter {
+	return v.WithLabelValues(v.orderedValues(labels)...)
+}
+
+// DeleteLabelValues removes the counter for the given, ordered label values.
+// It returns true if a counter was deleted.
+func (v *FixedPrecisionCounterVec) DeleteLabelValues(lvs ...string) bool {
+	return v.deleteLabelValues(lvs)
+}
+
+// Delete is the prometheus.Labels equivalent of DeleteLabelValues.
+func (v *FixedPrecisionCounterVec) Delete(labels prometheus.Labels) bool {
+	return v.deleteLabelValues(v.orderedValues(labels))
+}
+
+// Reset removes every counter from the vector.
+func (v *FixedPrecisionCounterVec) Reset() {
+	v.reset()
+}
+
+// FixedPrecisionGaugeVec is a prometheus.Collector that manages a
+// FixedPrecisionGauge per distinct combination of label values, the way
+// prometheus.GaugeVec does for regular gauges.
+type FixedPrecisionGaugeVec struct {
+	vec
+
+	opts prometheus.GaugeOpts
+	prec uint
+}
+
+// NewFixedPrecisionGaugeVec returns a populated fixed-precision gauge vector.
+func NewFixedPrecisionGaugeVec(opts prometheus.GaugeOpts, labelNames []string, prec uint) *FixedPrecisionGaugeVec {
+	return &FixedPrecisionGaugeVec{
+		vec: vec{
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+				opts.Help,
+				labelNames,
+				opts.ConstLabels,
+			),
+			labelNames: labelNames,
+		},
+		opts: opts,
+		prec: prec,
+	}
+}
+
+// WithLabelValues returns the gauge for the given, ordered label values,
+// creating it if this is the first time they've been observed.
+func (v *FixedPrecisionGaugeVec) WithLabelValues(lvs ...string) *FixedPrecisionGauge {
+	return v.child(lvs, func() vecChild {
+		return NewFixedPrecisionGauge(v.opts, v.prec)
+	}).(*FixedPrecisionGauge)
+}
+
+// With is the prometheus.Labels equivalent of WithLabelValues.
+func (v *FixedPrecisionGaugeVec) With(labels prometheus.Labels) *FixedPrecisionGauge {
+	return v.WithLabelValues(v.orderedValues(labels)...)
+}
+
+// DeleteLabelValues removes the gauge for the given, ordered label values. It
+// returns true if a gauge was deleted.
+func (v *FixedPrecisionGaugeVec) DeleteLabelValues(lvs ...string) bool {
+	return v.deleteLabelValues(lvs)
+}
+
+// Delete is the prometheus.Labels equivalent of DeleteLabelValues.
+func (v *FixedPrecisionGaugeVec) Delete(labels prometheus.Labels) bool {
+	return v.deleteLabelValues(v.orderedValues(labels))
+}
+
+// Reset removes every gauge from the vector.
+func (v *FixedPrecisionGaugeVec) Reset() {
+	v.reset()
+}
@@ -0,0 +1,110 @@
+package prom
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestFixedPrecisionCounterVecWithLabelValues(t *testing.T) {
+	v := NewFixedPrecisionCounterVec(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, []string{"method", "code"}, 0)
+
+	v.WithLabelValues("GET", "200").Inc()
+	v.WithLabelValues("GET", "200").Inc()
+	v.WithLabelValues("GET", "500").Inc()
+
+	if want, got := 2.0, v.WithLabelValues("GET", "200").Value(); want != got {
+		t.Errorf("Expected %v, got %v.", want, got)
+	}
+	if want, got := 1.0, v.WithLabelValues("GET", "500").Value(); want != got {
+		t.Errorf("Expected %v, got %v.", want, got)
+	}
+
+	ch := make(chan prometheus.Metric, 2)
+	v.Collect(ch)
+	close(ch)
+
+	count := 0
+	for m := range ch {
+		count++
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+		if len(out.Label) != 2 {
+			t.Errorf("expected 2 label pairs, got %d: %v", len(out.Label), out.Label)
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 children collected, got %d.", count)
+	}
+}
+
+func TestFixedPrecisionCounterVecWithLabelValuesCardinality(t *testing.T) {
+	v := NewFixedPrecisionCounterVec(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, []string{"method", "code"}, 0)
+
+	for _, lvs := range [][]string{
+		{"GET"},
+		{"GET", "200", "extra"},
+	} {
+		func() {
+			defer func() {
+				if e := recover(); e == nil {
+					t.Errorf("expected WithLabelValues(%v) to panic", lvs)
+				}
+			}()
+			v.WithLabelValues(lvs...)
+		}()
+	}
+}
+
+func TestFixedPrecisionCounterVecDelete(t *testing.T) {
+	v := NewFixedPrecisionCounterVec(prometheus.CounterOpts{
+		Name: "test",
+		Help: "test help",
+	}, []string{"method"}, 0)
+
+	v.WithLabelValues("GET").Inc()
+
+	if !v.DeleteLabelValues("GET") {
+		t.Fatalf("expected DeleteLabelValues to report deletion")
+	}
+	if v.DeleteLabelValues("GET") {
+		t.Fatalf("expected second DeleteLabelValues to report no-op")
+	}
+
+	// Fetching again after delete should start a fresh counter at zero.
+	if want, got := 0.0, v.WithLabelValues("GET").Value(); want != got {
+		t.Errorf("Expected %v, got %v.", want, got)
+	}
+}
+
+func TestFixedPrecisionGaugeVecWithAndReset(t *testing.T) {
+	v := NewFixedPrecisionGaugeVec(prometheus.GaugeOpts{
+		Name: "test",
+		Help: "test help",
+	}, []string{"shard"}, 2)
+
+	v.With(prometheus.Labels{"shard": "a"}).Set(4.5)
+	v.With(prometheus.Labels{"shard": "b"}).Set(9.5)
+
+	if want, got := 4.5, v.With(prometheus.Labels{"shard": "a"}).Value(); want != got {
+		t.Errorf("Expected %v, got %v.", want, got)
+	}
+
+	v.Reset()
+
+	ch := make(chan prometheus.Metric, 2)
+	v.Collect(ch)
+	close(ch)
+	if count := len(ch); count != 0 {
+		t.Errorf("Expected 0 children after Reset, got %d.", count)
+	}
+}